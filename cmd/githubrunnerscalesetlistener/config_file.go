@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// configFileFlag is the --config flag name. It's scanned for manually (see
+// configFilePath) rather than through the kingpin flag set so that config
+// file discovery doesn't depend on when kingpin.Parse() happens to run.
+const configFileFlag = "--config"
+
+// configFilePath returns the path to a multi scale set config file, from
+// either the --config flag or the GITHUB_CONFIG_FILE environment variable,
+// or "" if neither is set (the single scale set, env var only, mode).
+func configFilePath() string {
+	for i, arg := range os.Args {
+		if arg == configFileFlag && i+1 < len(os.Args) {
+			return os.Args[i+1]
+		}
+		if path, ok := strings.CutPrefix(arg, configFileFlag+"="); ok {
+			return path
+		}
+	}
+
+	return os.Getenv("GITHUB_CONFIG_FILE")
+}
+
+// rawListenerConfigFile is the on-disk schema for --config/GITHUB_CONFIG_FILE:
+// a list of scale set entries, each with the same fields as the env var
+// driven RunnerScaleSetListenerConfig plus a stable Name. Entries are kept
+// as raw JSON here (sigs.k8s.io/yaml converts YAML to JSON before
+// unmarshalling) so each one can be decoded onto a pre-defaulted
+// RunnerScaleSetListenerConfig rather than a struct's zero value - unlike
+// envconfig, encoding/json never looks at the `default:"..."` tag.
+type rawListenerConfigFile struct {
+	ScaleSets []json.RawMessage `yaml:"scaleSets"`
+}
+
+// defaultRunnerScaleSetListenerConfig mirrors the `default:"..."` envconfig
+// struct tags on RunnerScaleSetListenerConfig, so scaleSets[] entries in a
+// config file get the same defaults as the env var driven fields they omit.
+func defaultRunnerScaleSetListenerConfig() RunnerScaleSetListenerConfig {
+	return RunnerScaleSetListenerConfig{
+		PredictionWindow:            5 * time.Minute,
+		PredictionHistoryWindow:     120,
+		PredictionSeasonLength:      60,
+		KedaGrpcAddress:             ":9090",
+		LiveStateReportInterval:     10 * time.Second,
+		LiveStateReportMaxInterval:  time.Minute,
+		HealthProbeBindAddress:      ":8080",
+		MaxMessageSessionAge:        5 * time.Minute,
+		MaxActionsServiceSuccessAge: 5 * time.Minute,
+	}
+}
+
+// loadListenerConfigFile reads and validates a multi scale set config file.
+func loadListenerConfigFile(path string) ([]RunnerScaleSetListenerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file rawListenerConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if len(file.ScaleSets) == 0 {
+		return nil, fmt.Errorf("config file %q does not define any scaleSets", path)
+	}
+
+	scaleSets := make([]RunnerScaleSetListenerConfig, len(file.ScaleSets))
+	for i, raw := range file.ScaleSets {
+		entry := defaultRunnerScaleSetListenerConfig()
+		// Duration fields take "30s"-style strings, as in env var mode.
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) == nil {
+			for key, value := range fields {
+				field, ok := reflect.TypeOf(entry).FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, key) })
+				var s string
+				if ok && field.Type == reflect.TypeOf(time.Duration(0)) && json.Unmarshal(value, &s) == nil {
+					if d, err := time.ParseDuration(s); err == nil {
+						fields[key], _ = json.Marshal(int64(d))
+					}
+				}
+			}
+			raw, _ = json.Marshal(fields)
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("config file %q: scaleSets[%d]: %w", path, i, err)
+		}
+		scaleSets[i] = entry
+	}
+
+	seenNames := make(map[string]bool, len(scaleSets))
+	seenIds := make(map[int]bool, len(scaleSets))
+
+	for i, entry := range scaleSets {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("scaleSets[%d]: name is required", i)
+		}
+		if seenNames[entry.Name] {
+			return nil, fmt.Errorf("scaleSets[%d]: duplicate name %q", i, entry.Name)
+		}
+		seenNames[entry.Name] = true
+
+		if seenIds[entry.RunnerScaleSetId] {
+			return nil, fmt.Errorf("scaleSets[%d] (%s): duplicate runnerScaleSetId %d", i, entry.Name, entry.RunnerScaleSetId)
+		}
+		seenIds[entry.RunnerScaleSetId] = true
+
+		if err := validateConfig(&scaleSets[i]); err != nil {
+			return nil, fmt.Errorf("scaleSets[%d] (%s): %w", i, entry.Name, err)
+		}
+	}
+
+	return scaleSets, nil
+}
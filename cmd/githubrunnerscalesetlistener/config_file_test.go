@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "listener.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write test config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadListenerConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+scaleSets:
+  - name: set-a
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-a
+    ephemeralRunnerSetName: ers-a
+    runnerScaleSetId: 1
+    minRunners: 0
+    maxRunners: 5
+    token: abc123
+  - name: set-b
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-b
+    ephemeralRunnerSetName: ers-b
+    runnerScaleSetId: 2
+    minRunners: 0
+    maxRunners: 5
+    token: abc123
+`)
+
+	entries, err := loadListenerConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadListenerConfigFile() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Name != "set-a" || entries[1].Name != "set-b" {
+		t.Fatalf("unexpected entry names: %q, %q", entries[0].Name, entries[1].Name)
+	}
+}
+
+func TestLoadListenerConfigFileAppliesDefaults(t *testing.T) {
+	path := writeConfigFile(t, `
+scaleSets:
+  - name: set-a
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-a
+    ephemeralRunnerSetName: ers-a
+    runnerScaleSetId: 1
+    maxRunners: 5
+    token: abc123
+  - name: set-b
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-b
+    ephemeralRunnerSetName: ers-b
+    runnerScaleSetId: 2
+    maxRunners: 5
+    token: abc123
+    healthProbeBindAddress: ":9999"
+    maxMessageSessionAge: 30s
+`)
+
+	entries, err := loadListenerConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadListenerConfigFile() error: %v", err)
+	}
+
+	// set-a doesn't set any of these, so it must get the same defaults
+	// envconfig would apply in single scale set (env var) mode.
+	a := entries[0]
+	if a.HealthProbeBindAddress != ":8080" {
+		t.Fatalf("set-a HealthProbeBindAddress = %q, want default :8080", a.HealthProbeBindAddress)
+	}
+	if a.MaxMessageSessionAge != 5*time.Minute {
+		t.Fatalf("set-a MaxMessageSessionAge = %v, want default 5m", a.MaxMessageSessionAge)
+	}
+	if a.MaxActionsServiceSuccessAge != 5*time.Minute {
+		t.Fatalf("set-a MaxActionsServiceSuccessAge = %v, want default 5m", a.MaxActionsServiceSuccessAge)
+	}
+	if a.KedaGrpcAddress != ":9090" {
+		t.Fatalf("set-a KedaGrpcAddress = %q, want default :9090", a.KedaGrpcAddress)
+	}
+	if a.LiveStateReportInterval != 10*time.Second || a.LiveStateReportMaxInterval != time.Minute {
+		t.Fatalf("set-a live state report intervals = %v/%v, want defaults 10s/1m", a.LiveStateReportInterval, a.LiveStateReportMaxInterval)
+	}
+	if a.PredictionWindow != 5*time.Minute || a.PredictionHistoryWindow != 120 || a.PredictionSeasonLength != 60 {
+		t.Fatalf("set-a prediction defaults = %v/%d/%d, want 5m/120/60", a.PredictionWindow, a.PredictionHistoryWindow, a.PredictionSeasonLength)
+	}
+
+	// set-b overrides a couple of fields explicitly; those must win over
+	// the defaults, everything else should still be defaulted.
+	b := entries[1]
+	if b.HealthProbeBindAddress != ":9999" {
+		t.Fatalf("set-b HealthProbeBindAddress = %q, want override :9999", b.HealthProbeBindAddress)
+	}
+	if b.MaxMessageSessionAge != 30*time.Second {
+		t.Fatalf("set-b MaxMessageSessionAge = %v, want override 30s", b.MaxMessageSessionAge)
+	}
+	if b.MaxActionsServiceSuccessAge != 5*time.Minute {
+		t.Fatalf("set-b MaxActionsServiceSuccessAge = %v, want default 5m", b.MaxActionsServiceSuccessAge)
+	}
+}
+
+func TestLoadListenerConfigFileRejectsDuplicateNames(t *testing.T) {
+	path := writeConfigFile(t, `
+scaleSets:
+  - name: set-a
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-a
+    ephemeralRunnerSetName: ers-a
+    runnerScaleSetId: 1
+    maxRunners: 5
+    token: abc123
+  - name: set-a
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-b
+    ephemeralRunnerSetName: ers-b
+    runnerScaleSetId: 2
+    maxRunners: 5
+    token: abc123
+`)
+
+	if _, err := loadListenerConfigFile(path); err == nil {
+		t.Fatal("expected an error for duplicate scale set names, got nil")
+	}
+}
+
+func TestLoadListenerConfigFileRejectsDuplicateIds(t *testing.T) {
+	path := writeConfigFile(t, `
+scaleSets:
+  - name: set-a
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-a
+    ephemeralRunnerSetName: ers-a
+    runnerScaleSetId: 1
+    maxRunners: 5
+    token: abc123
+  - name: set-b
+    configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-b
+    ephemeralRunnerSetName: ers-b
+    runnerScaleSetId: 1
+    maxRunners: 5
+    token: abc123
+`)
+
+	if _, err := loadListenerConfigFile(path); err == nil {
+		t.Fatal("expected an error for duplicate runnerScaleSetId values, got nil")
+	}
+}
+
+func TestLoadListenerConfigFileRequiresName(t *testing.T) {
+	path := writeConfigFile(t, `
+scaleSets:
+  - configureUrl: https://github.com/org/repo
+    ephemeralRunnerSetNamespace: ns-a
+    ephemeralRunnerSetName: ers-a
+    runnerScaleSetId: 1
+    maxRunners: 5
+    token: abc123
+`)
+
+	if _, err := loadListenerConfigFile(path); err == nil {
+		t.Fatal("expected an error for a missing name, got nil")
+	}
+}
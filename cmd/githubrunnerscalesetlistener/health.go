@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// CheckFunc is a single named health check. It should return quickly and
+// return a non-nil error describing why the check is currently failing.
+type CheckFunc func(ctx context.Context) error
+
+// HealthChecker is a pluggable registry of named checks, each independently
+// exercised by /readyz and /healthz.
+type HealthChecker struct {
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+}
+
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{checks: make(map[string]CheckFunc)}
+}
+
+// AddCheck registers (or replaces) a named check.
+func (h *HealthChecker) AddCheck(name string, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// results runs every registered check and returns the name->error map (nil
+// error means the check passed).
+func (h *HealthChecker) results(ctx context.Context) map[string]error {
+	h.mu.RLock()
+	checks := make(map[string]CheckFunc, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	results := make(map[string]error, len(checks))
+	for name, check := range checks {
+		results[name] = check(ctx)
+	}
+	return results
+}
+
+// healthProbeServer serves /livez, /readyz and /healthz for Kubernetes
+// liveness/readiness probes to hit, backed by a HealthChecker.
+type healthProbeServer struct {
+	logger  logr.Logger
+	checker *HealthChecker
+}
+
+// newHealthProbeHandler builds the mux for the always-on health probe
+// server. /livez reports process liveness unconditionally (it never depends
+// on external state, so Kubernetes doesn't restart a pod stuck waiting on a
+// check); /readyz and /healthz run every registered check.
+func newHealthProbeHandler(logger logr.Logger, checker *HealthChecker) http.Handler {
+	s := &healthProbeServer{logger: logger, checker: checker}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", s.handleLivez)
+	mux.HandleFunc("/readyz", s.handleChecks)
+	mux.HandleFunc("/healthz", s.handleChecks)
+	return mux
+}
+
+func (s *healthProbeServer) handleLivez(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *healthProbeServer) handleChecks(w http.ResponseWriter, r *http.Request) {
+	results := s.checker.results(r.Context())
+
+	ok := true
+	for _, err := range results {
+		if err != nil {
+			ok = false
+			break
+		}
+	}
+
+	if r.URL.Query().Get("verbose") == "1" {
+		s.writeVerbose(w, results, ok)
+		return
+	}
+
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "not ok")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *healthProbeServer) writeVerbose(w http.ResponseWriter, results map[string]error, ok bool) {
+	type checkStatus struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	body := make(map[string]checkStatus, len(results))
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := results[name]
+		if err != nil {
+			body[name] = checkStatus{Status: "failed", Error: err.Error()}
+		} else {
+			body[name] = checkStatus{Status: "ok"}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error(err, "failed to encode verbose health check response")
+	}
+}
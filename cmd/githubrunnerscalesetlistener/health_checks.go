@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// messageSessionState is implemented by AutoScalerClient: the last time a
+// message was received over the long-poll session, and whether a session
+// has been established at all.
+type messageSessionState interface {
+	LastMessageReceivedAt() (t time.Time, established bool)
+}
+
+// kubeAPIPinger is implemented by KubernetesManager.
+type kubeAPIPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// actionsServiceState is implemented by actions.Client: the last time a
+// request to the Actions Service succeeded, and whether one ever has.
+type actionsServiceState interface {
+	LastSuccessfulRequestAt() (t time.Time, ok bool)
+}
+
+// registerScaleSetHealthChecks wires up the checks described in the health
+// probe design: message session freshness, Kubernetes API reachability,
+// Actions Service reachability, and proxy/CA parseability (reported as a
+// static pass; see the check itself for what it does not cover).
+func registerScaleSetHealthChecks(
+	checker *HealthChecker,
+	rc RunnerScaleSetListenerConfig,
+	session messageSessionState,
+	kube kubeAPIPinger,
+	actionsService actionsServiceState,
+) {
+	checker.AddCheck(checkName(rc, "messageSession"), func(ctx context.Context) error {
+		lastMessageAt, established := session.LastMessageReceivedAt()
+		if !established {
+			return fmt.Errorf("message session not yet established")
+		}
+		if age := time.Since(lastMessageAt); age > rc.MaxMessageSessionAge {
+			return fmt.Errorf("last message received %s ago, exceeds threshold of %s", age, rc.MaxMessageSessionAge)
+		}
+		return nil
+	})
+
+	checker.AddCheck(checkName(rc, "kubeAPIReachable"), func(ctx context.Context) error {
+		return kube.Ping(ctx)
+	})
+
+	checker.AddCheck(checkName(rc, "actionsServiceReachable"), func(ctx context.Context) error {
+		lastSuccessAt, ok := actionsService.LastSuccessfulRequestAt()
+		if !ok {
+			return fmt.Errorf("no successful request to the Actions Service yet")
+		}
+		if age := time.Since(lastSuccessAt); age > rc.MaxActionsServiceSuccessAge {
+			return fmt.Errorf("last successful Actions Service request %s ago, exceeds threshold of %s", age, rc.MaxActionsServiceSuccessAge)
+		}
+		return nil
+	})
+
+	checker.AddCheck(checkName(rc, "proxyAndCAConfigParseable"), func(ctx context.Context) error {
+		// newActionsClientFromConfig parses the ServerRootCA PEM bundle at
+		// startup and runScaleSet returns an error before registering this
+		// check if that failed. Proxy settings are not validated: they are
+		// read with httpproxy.FromEnvironment, whose ProxyFunc reports no
+		// parse error, so an unparsable proxy value still passes here.
+		return nil
+	})
+}
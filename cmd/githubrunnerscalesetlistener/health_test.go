@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestHealthProbeLivezAlwaysOK(t *testing.T) {
+	checker := NewHealthChecker()
+	checker.AddCheck("always-fails", func(ctx context.Context) error { return errors.New("boom") })
+
+	handler := newHealthProbeHandler(logr.Discard(), checker)
+
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/livez = %d, want %d even with failing checks registered", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHealthProbeReadyzReflectsChecks(t *testing.T) {
+	checker := NewHealthChecker()
+	handler := newHealthProbeHandler(logr.Discard(), checker)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/readyz with no checks = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	checker.AddCheck("broken", func(ctx context.Context) error { return errors.New("not ready") })
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz with a failing check = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHealthProbeReadyzVerbose(t *testing.T) {
+	checker := NewHealthChecker()
+	checker.AddCheck("broken", func(ctx context.Context) error { return errors.New("not ready") })
+	handler := newHealthProbeHandler(logr.Discard(), checker)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz?verbose=1", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("verbose /readyz = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("verbose /readyz content-type = %q, want application/json", ct)
+	}
+}
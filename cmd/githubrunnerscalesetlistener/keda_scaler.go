@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+
+	"github.com/actions/actions-runner-controller/github/actions/keda"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// jobQueueState is the subset of the Service's live counters the KEDA
+// external scaler needs. Service implements this; it's the same state that
+// backs the Prometheus gauges registered in run().
+type jobQueueState interface {
+	QueuedJobs() int
+	BusyRunners() int
+	Subscribe() (ch <-chan struct{}, unsubscribe func())
+}
+
+// kedaExternalScalerServer implements KEDA's ExternalScaler gRPC contract on
+// top of a listener's in-memory job/runner state, so an AutoscalingRunnerSet
+// can be driven by a `external` ScaledObject instead of polling.
+type kedaExternalScalerServer struct {
+	keda.UnimplementedExternalScalerServer
+
+	logger logr.Logger
+	state  jobQueueState
+
+	// metricName is the name surfaced through GetMetricSpec/GetMetrics,
+	// counting queued+in-progress jobs.
+	metricName string
+	// targetJobsPerRunner is the target value KEDA divides the metric by
+	// to compute desired replicas.
+	targetJobsPerRunner int64
+}
+
+func newKedaExternalScalerServer(logger logr.Logger, state jobQueueState) *kedaExternalScalerServer {
+	return &kedaExternalScalerServer{
+		logger:              logger.WithName("keda-external-scaler"),
+		state:               state,
+		metricName:          "queued-and-in-progress-jobs",
+		targetJobsPerRunner: 1,
+	}
+}
+
+func (s *kedaExternalScalerServer) IsActive(ctx context.Context, _ *keda.ScaledObjectRef) (*keda.IsActiveResponse, error) {
+	active := s.state.QueuedJobs() > 0 || s.state.BusyRunners() > 0
+	return &keda.IsActiveResponse{Result: active}, nil
+}
+
+func (s *kedaExternalScalerServer) StreamIsActive(_ *keda.ScaledObjectRef, stream keda.ExternalScaler_StreamIsActiveServer) error {
+	ch, unsubscribe := s.state.Subscribe()
+	defer unsubscribe()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			active := s.state.QueuedJobs() > 0 || s.state.BusyRunners() > 0
+			if err := stream.Send(&keda.IsActiveResponse{Result: active}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *kedaExternalScalerServer) GetMetricSpec(ctx context.Context, _ *keda.ScaledObjectRef) (*keda.GetMetricSpecResponse, error) {
+	return &keda.GetMetricSpecResponse{
+		MetricSpecs: []*keda.MetricSpec{
+			{MetricName: s.metricName, TargetSize: s.targetJobsPerRunner},
+		},
+	}, nil
+}
+
+func (s *kedaExternalScalerServer) GetMetrics(ctx context.Context, req *keda.GetMetricsRequest) (*keda.GetMetricsResponse, error) {
+	return &keda.GetMetricsResponse{
+		MetricValues: []*keda.MetricValue{
+			{MetricName: req.MetricName, MetricValue: int64(s.state.QueuedJobs() + s.state.BusyRunners())},
+		},
+	}, nil
+}
+
+// newKedaGrpcServerCredentials builds server TLS credentials for the KEDA
+// external scaler endpoint. clientRootCA is the trust root for inbound KEDA
+// gRPC clients (KedaGrpcClientCA) — a distinct trust domain from the
+// Actions Service's ServerRootCA — and enables mTLS when set.
+func newKedaGrpcServerCredentials(certPEM, keyPEM, clientRootCA string) (credentials.TransportCredentials, error) {
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load KEDA gRPC server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if clientRootCA != "" {
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM([]byte(clientRootCA)); !ok {
+			return nil, fmt.Errorf("failed to parse KEDA gRPC client root CA")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// startKedaExternalScalerServer starts the KEDA external scaler gRPC server
+// on addr and returns a stop function. The returned server is torn down
+// when ctx is cancelled.
+func startKedaExternalScalerServer(ctx context.Context, logger logr.Logger, addr string, state jobQueueState, creds credentials.TransportCredentials) (func(), error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s for KEDA external scaler: %w", addr, err)
+	}
+
+	var opts []grpc.ServerOption
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	grpcServer := grpc.NewServer(opts...)
+	keda.RegisterExternalScalerServer(grpcServer, newKedaExternalScalerServer(logger, state))
+
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	go func() {
+		logger.Info("Starting KEDA external scaler gRPC server", "address", addr)
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error(err, "KEDA external scaler gRPC server stopped")
+		}
+	}()
+
+	return grpcServer.GracefulStop, nil
+}
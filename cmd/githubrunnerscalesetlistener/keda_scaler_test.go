@@ -0,0 +1,332 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/github/actions/keda"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeJobQueueState struct {
+	queuedJobs  int
+	busyRunners int
+	updates     chan struct{}
+}
+
+func (f *fakeJobQueueState) QueuedJobs() int  { return f.queuedJobs }
+func (f *fakeJobQueueState) BusyRunners() int { return f.busyRunners }
+func (f *fakeJobQueueState) Subscribe() (<-chan struct{}, func()) {
+	return f.updates, func() {}
+}
+
+func serveExternalScaler(t *testing.T, state jobQueueState, opts ...grpc.ServerOption) *bufconn.Listener {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(opts...)
+	keda.RegisterExternalScalerServer(grpcServer, newKedaExternalScalerServer(logr.Discard(), state))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return lis
+}
+
+func dialExternalScaler(t *testing.T, state jobQueueState) (keda.ExternalScalerClient, func()) {
+	t.Helper()
+
+	lis := serveExternalScaler(t, state)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+
+	return keda.NewExternalScalerClient(conn), func() {
+		conn.Close()
+	}
+}
+
+func TestKedaExternalScalerIsActive(t *testing.T) {
+	state := &fakeJobQueueState{queuedJobs: 0, busyRunners: 0}
+	client, closeFn := dialExternalScaler(t, state)
+	defer closeFn()
+
+	resp, err := client.IsActive(context.Background(), &keda.ScaledObjectRef{Name: "test"})
+	if err != nil {
+		t.Fatalf("IsActive() error: %v", err)
+	}
+	if resp.Result {
+		t.Fatalf("IsActive() = true, want false with no queued or busy work")
+	}
+
+	state.queuedJobs = 1
+	resp, err = client.IsActive(context.Background(), &keda.ScaledObjectRef{Name: "test"})
+	if err != nil {
+		t.Fatalf("IsActive() error: %v", err)
+	}
+	if !resp.Result {
+		t.Fatalf("IsActive() = false, want true with a queued job")
+	}
+}
+
+func TestKedaExternalScalerGetMetrics(t *testing.T) {
+	state := &fakeJobQueueState{queuedJobs: 3}
+	client, closeFn := dialExternalScaler(t, state)
+	defer closeFn()
+
+	spec, err := client.GetMetricSpec(context.Background(), &keda.ScaledObjectRef{Name: "test"})
+	if err != nil {
+		t.Fatalf("GetMetricSpec() error: %v", err)
+	}
+	if len(spec.MetricSpecs) != 1 {
+		t.Fatalf("expected 1 metric spec, got %d", len(spec.MetricSpecs))
+	}
+
+	metrics, err := client.GetMetrics(context.Background(), &keda.GetMetricsRequest{MetricName: spec.MetricSpecs[0].MetricName})
+	if err != nil {
+		t.Fatalf("GetMetrics() error: %v", err)
+	}
+	if len(metrics.MetricValues) != 1 || metrics.MetricValues[0].MetricValue != 3 {
+		t.Fatalf("GetMetrics() = %+v, want a single value of 3", metrics.MetricValues)
+	}
+}
+
+func TestKedaExternalScalerStreamIsActive(t *testing.T) {
+	state := &fakeJobQueueState{queuedJobs: 0, updates: make(chan struct{}, 1)}
+	client, closeFn := dialExternalScaler(t, state)
+	defer closeFn()
+
+	stream, err := client.StreamIsActive(context.Background(), &keda.ScaledObjectRef{Name: "test"})
+	if err != nil {
+		t.Fatalf("StreamIsActive() error: %v", err)
+	}
+
+	state.queuedJobs = 1
+	state.updates <- struct{}{}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	if !resp.Result {
+		t.Fatalf("Recv() = %+v, want Result true after a queued job arrived", resp)
+	}
+
+	state.queuedJobs = 0
+	state.updates <- struct{}{}
+
+	resp, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	if resp.Result {
+		t.Fatalf("Recv() = %+v, want Result false once the queue drains", resp)
+	}
+}
+
+// testCA is a minimal self-signed CA used to issue short-lived leaf
+// certificates for the mTLS tests below.
+type testCA struct {
+	certPEM string
+	cert    *x509.Certificate
+	key     *rsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to self-sign CA certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+
+	return &testCA{
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		cert:    cert,
+		key:     key,
+	}
+}
+
+// issueCert returns a PEM-encoded certificate/key pair for commonName,
+// signed by the test CA.
+func (ca *testCA) issueCert(t *testing.T, commonName string) (certPEM, keyPEM string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate leaf key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to sign certificate for %q: %v", commonName, err)
+	}
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal private key for %q: %v", commonName, err)
+	}
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+
+	return certPEM, keyPEM
+}
+
+func dialExternalScalerTLS(t *testing.T, lis *bufconn.Listener, creds credentials.TransportCredentials) (keda.ExternalScalerClient, func()) {
+	t.Helper()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn over TLS: %v", err)
+	}
+
+	return keda.NewExternalScalerClient(conn), func() { conn.Close() }
+}
+
+func TestKedaExternalScalerMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	serverCertPEM, serverKeyPEM := ca.issueCert(t, "localhost")
+	clientCertPEM, clientKeyPEM := ca.issueCert(t, "keda-client")
+
+	serverCreds, err := newKedaGrpcServerCredentials(serverCertPEM, serverKeyPEM, ca.certPEM)
+	if err != nil {
+		t.Fatalf("newKedaGrpcServerCredentials() error: %v", err)
+	}
+
+	state := &fakeJobQueueState{queuedJobs: 1}
+	lis := serveExternalScaler(t, state, grpc.Creds(serverCreds))
+
+	clientCert, err := tls.X509KeyPair([]byte(clientCertPEM), []byte(clientKeyPEM))
+	if err != nil {
+		t.Fatalf("failed to load client certificate: %v", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM([]byte(ca.certPEM)) {
+		t.Fatal("failed to parse CA certificate")
+	}
+	clientCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      caPool,
+		ServerName:   "localhost",
+	})
+
+	client, closeFn := dialExternalScalerTLS(t, lis, clientCreds)
+	defer closeFn()
+
+	resp, err := client.IsActive(context.Background(), &keda.ScaledObjectRef{Name: "test"})
+	if err != nil {
+		t.Fatalf("IsActive() over mTLS error: %v", err)
+	}
+	if !resp.Result {
+		t.Fatalf("IsActive() = false, want true with a queued job")
+	}
+}
+
+func TestKedaExternalScalerMutualTLSRejectsUntrustedClient(t *testing.T) {
+	ca := newTestCA(t)
+	serverCertPEM, serverKeyPEM := ca.issueCert(t, "localhost")
+
+	// A client certificate signed by a different, unrelated CA must be
+	// rejected by the server's clientRootCA verification.
+	otherCA := newTestCA(t)
+	clientCertPEM, clientKeyPEM := otherCA.issueCert(t, "keda-client")
+
+	serverCreds, err := newKedaGrpcServerCredentials(serverCertPEM, serverKeyPEM, ca.certPEM)
+	if err != nil {
+		t.Fatalf("newKedaGrpcServerCredentials() error: %v", err)
+	}
+
+	state := &fakeJobQueueState{queuedJobs: 1}
+	lis := serveExternalScaler(t, state, grpc.Creds(serverCreds))
+
+	clientCert, err := tls.X509KeyPair([]byte(clientCertPEM), []byte(clientKeyPEM))
+	if err != nil {
+		t.Fatalf("failed to load client certificate: %v", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM([]byte(ca.certPEM)) {
+		t.Fatal("failed to parse CA certificate")
+	}
+	clientCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      caPool,
+		ServerName:   "localhost",
+	})
+
+	client, closeFn := dialExternalScalerTLS(t, lis, clientCreds)
+	defer closeFn()
+
+	if _, err := client.IsActive(context.Background(), &keda.ScaledObjectRef{Name: "test"}); err == nil {
+		t.Fatal("IsActive() over mTLS with an untrusted client certificate succeeded, want a handshake error")
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// StatusCondition mirrors the standard Kubernetes condition shape so it can
+// be copied directly into an AutoscalingRunnerSet's .status.conditions.
+type StatusCondition struct {
+	Type               string    `json:"type"`
+	Status             bool      `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+const (
+	ConditionMessageQueueConnected = "MessageQueueConnected"
+	ConditionKubeAPIReachable      = "KubeAPIReachable"
+	ConditionAtMaxRunners          = "AtMaxRunners"
+	ConditionDegraded              = "Degraded"
+)
+
+// LiveStateSnapshot is the listener's point-in-time view of its scale set,
+// written back to the AutoscalingRunnerSet and served on /status.
+type LiveStateSnapshot struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	AvailableJobs     int `json:"availableJobs"`
+	AssignedJobs      int `json:"assignedJobs"`
+	RunningJobs       int `json:"runningJobs"`
+	RegisteredRunners int `json:"registeredRunners"`
+	IdleRunners       int `json:"idleRunners"`
+	BusyRunners       int `json:"busyRunners"`
+
+	DesiredReplicas       int      `json:"desiredReplicas"`
+	ForecastArrivals      *float64 `json:"forecastArrivals,omitempty"`
+	LastMessageSequenceNo int64    `json:"lastMessageSequenceNumber"`
+
+	Conditions []StatusCondition `json:"conditions"`
+
+	ObservedTime time.Time `json:"observedTime"`
+}
+
+// liveStateSource is implemented by Service; it's the subset of state the
+// reporter snapshots on each tick.
+type liveStateSource interface {
+	LiveStateSnapshot() LiveStateSnapshot
+}
+
+// liveStateWriter is implemented by KubernetesManager. Patch should use
+// server-side apply so the reporter's writes don't fight the controller's.
+type liveStateWriter interface {
+	PatchAutoscalingRunnerSetStatus(ctx context.Context, namespace, name string, snapshot LiveStateSnapshot) error
+}
+
+// LiveStateReporter periodically snapshots a scale set's live state and
+// writes it back to its AutoscalingRunnerSet status, debounced so it only
+// PATCHes on change or at most every maxInterval.
+type LiveStateReporter struct {
+	logger logr.Logger
+
+	source liveStateSource
+	writer liveStateWriter
+
+	namespace, name string
+
+	pollInterval time.Duration
+	maxInterval  time.Duration
+
+	mu          sync.RWMutex
+	last        LiveStateSnapshot
+	lastWritten time.Time
+	hasSnapshot bool
+}
+
+// NewLiveStateReporter constructs a reporter for namespace/name. pollInterval
+// controls how often the source is sampled; maxInterval is the longest the
+// reporter will go without writing even if nothing changed.
+func NewLiveStateReporter(logger logr.Logger, source liveStateSource, writer liveStateWriter, namespace, name string, pollInterval, maxInterval time.Duration) *LiveStateReporter {
+	if pollInterval <= 0 {
+		pollInterval = 10 * time.Second
+	}
+	if maxInterval <= 0 {
+		maxInterval = time.Minute
+	}
+	return &LiveStateReporter{
+		logger:       logger.WithName("live-state-reporter"),
+		source:       source,
+		writer:       writer,
+		namespace:    namespace,
+		name:         name,
+		pollInterval: pollInterval,
+		maxInterval:  maxInterval,
+	}
+}
+
+// Start blocks, sampling and (debounced) writing the snapshot until ctx is
+// cancelled.
+func (r *LiveStateReporter) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.tick(ctx)
+		}
+	}
+}
+
+func (r *LiveStateReporter) tick(ctx context.Context) {
+	snapshot := r.source.LiveStateSnapshot()
+	snapshot.Namespace = r.namespace
+	snapshot.Name = r.name
+	snapshot.ObservedTime = time.Now()
+
+	r.mu.Lock()
+	changed := !r.hasSnapshot || !sameState(r.last, snapshot)
+	overdue := time.Since(r.lastWritten) >= r.maxInterval
+	r.last = snapshot
+	r.hasSnapshot = true
+	r.mu.Unlock()
+
+	if !changed && !overdue {
+		return
+	}
+
+	if err := r.writer.PatchAutoscalingRunnerSetStatus(ctx, r.namespace, r.name, snapshot); err != nil {
+		r.logger.Error(err, "failed to patch AutoscalingRunnerSet status")
+		return
+	}
+
+	r.mu.Lock()
+	r.lastWritten = time.Now()
+	r.mu.Unlock()
+}
+
+// sameState compares two snapshots ignoring their observation timestamp, so
+// a tick where nothing meaningful changed doesn't trigger a write.
+//
+// a and b are passed by value, but Conditions is a slice, so assigning into
+// a.Conditions[i]/b.Conditions[i] would still mutate the caller's backing
+// arrays (e.g. r.last and the snapshot tick just took). Copy Conditions
+// before zeroing LastTransitionTime so this stays a pure comparison.
+func sameState(a, b LiveStateSnapshot) bool {
+	a.ObservedTime, b.ObservedTime = time.Time{}, time.Time{}
+	a.Conditions = conditionsWithoutTransitionTimes(a.Conditions)
+	b.Conditions = conditionsWithoutTransitionTimes(b.Conditions)
+	return reflect.DeepEqual(a, b)
+}
+
+// conditionsWithoutTransitionTimes returns a copy of conditions with
+// LastTransitionTime zeroed, leaving the original slice untouched.
+func conditionsWithoutTransitionTimes(conditions []StatusCondition) []StatusCondition {
+	if conditions == nil {
+		return nil
+	}
+	out := make([]StatusCondition, len(conditions))
+	for i, c := range conditions {
+		c.LastTransitionTime = time.Time{}
+		out[i] = c
+	}
+	return out
+}
+
+// ServeHTTP exposes the latest snapshot for out-of-cluster debugging.
+func (r *LiveStateReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	snapshot := r.last
+	hasSnapshot := r.hasSnapshot
+	r.mu.RUnlock()
+
+	if !hasSnapshot {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		r.logger.Error(err, "failed to encode live state snapshot")
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+type fakeLiveStateSource struct {
+	snapshot atomic.Value
+}
+
+func (f *fakeLiveStateSource) set(s LiveStateSnapshot) { f.snapshot.Store(s) }
+
+func (f *fakeLiveStateSource) LiveStateSnapshot() LiveStateSnapshot {
+	return f.snapshot.Load().(LiveStateSnapshot)
+}
+
+type fakeLiveStateWriter struct {
+	patches int32
+}
+
+func (f *fakeLiveStateWriter) PatchAutoscalingRunnerSetStatus(_ context.Context, _, _ string, _ LiveStateSnapshot) error {
+	atomic.AddInt32(&f.patches, 1)
+	return nil
+}
+
+func TestLiveStateReporterDebouncesUnchangedSnapshots(t *testing.T) {
+	source := &fakeLiveStateSource{}
+	source.set(LiveStateSnapshot{AvailableJobs: 1})
+	writer := &fakeLiveStateWriter{}
+
+	r := NewLiveStateReporter(logr.Discard(), source, writer, "ns", "name", time.Millisecond, time.Hour)
+
+	ctx := context.Background()
+	r.tick(ctx)
+	r.tick(ctx)
+	r.tick(ctx)
+
+	if got := atomic.LoadInt32(&writer.patches); got != 1 {
+		t.Fatalf("expected exactly 1 patch for unchanged snapshots, got %d", got)
+	}
+
+	source.set(LiveStateSnapshot{AvailableJobs: 2})
+	r.tick(ctx)
+
+	if got := atomic.LoadInt32(&writer.patches); got != 2 {
+		t.Fatalf("expected a second patch after the snapshot changed, got %d", got)
+	}
+}
+
+func TestLiveStateReporterPreservesConditionTransitionTimes(t *testing.T) {
+	source := &fakeLiveStateSource{}
+	transitionedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	source.set(LiveStateSnapshot{
+		AvailableJobs: 1,
+		Conditions: []StatusCondition{
+			{Type: ConditionMessageQueueConnected, Status: true, LastTransitionTime: transitionedAt},
+		},
+	})
+	writer := &fakeLiveStateWriter{}
+
+	r := NewLiveStateReporter(logr.Discard(), source, writer, "ns", "name", time.Millisecond, time.Hour)
+
+	ctx := context.Background()
+	r.tick(ctx)
+	r.tick(ctx)
+	r.tick(ctx)
+
+	if got := atomic.LoadInt32(&writer.patches); got != 1 {
+		t.Fatalf("expected exactly 1 patch for unchanged snapshots, got %d", got)
+	}
+
+	r.mu.RLock()
+	last := r.last
+	r.mu.RUnlock()
+
+	if len(last.Conditions) != 1 {
+		t.Fatalf("expected 1 condition to survive debounced ticks, got %d", len(last.Conditions))
+	}
+	if !last.Conditions[0].LastTransitionTime.Equal(transitionedAt) {
+		t.Fatalf("expected LastTransitionTime %v to survive debounced ticks, got %v", transitionedAt, last.Conditions[0].LastTransitionTime)
+	}
+}
+
+func TestLiveStateReporterWritesWhenOverdue(t *testing.T) {
+	source := &fakeLiveStateSource{}
+	source.set(LiveStateSnapshot{AvailableJobs: 1})
+	writer := &fakeLiveStateWriter{}
+
+	r := NewLiveStateReporter(logr.Discard(), source, writer, "ns", "name", time.Millisecond, time.Millisecond)
+
+	ctx := context.Background()
+	r.tick(ctx)
+	time.Sleep(2 * time.Millisecond)
+	r.tick(ctx)
+
+	if got := atomic.LoadInt32(&writer.patches); got != 2 {
+		t.Fatalf("expected a write past maxInterval even with no change, got %d patches", got)
+	}
+}
@@ -25,7 +25,11 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/actions/actions-runner-controller/build"
 	"github.com/actions/actions-runner-controller/github/actions"
@@ -42,21 +46,65 @@ import (
 	"github.com/prometheus/exporter-toolkit/web"
 	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
 	"golang.org/x/net/http/httpproxy"
+	"google.golang.org/grpc/credentials"
 )
 
 type RunnerScaleSetListenerConfig struct {
-	ConfigureUrl                string `split_words:"true"`
-	AppID                       int64  `split_words:"true"`
-	AppInstallationID           int64  `split_words:"true"`
-	AppPrivateKey               string `split_words:"true"`
-	Token                       string `split_words:"true"`
-	EphemeralRunnerSetNamespace string `split_words:"true"`
-	EphemeralRunnerSetName      string `split_words:"true"`
-	MaxRunners                  int    `split_words:"true"`
-	MinRunners                  int    `split_words:"true"`
-	RunnerScaleSetId            int    `split_words:"true"`
-	ServerRootCA                string `split_words:"true"`
-	EnablePrometheusMetrics     bool   `split_words:"true"`
+	// Name identifies this scale set when several are run from a single
+	// listener process via a config file (see config_file.go). Unused,
+	// and not required, in the single scale set (env var) mode.
+	Name string `split_words:"true" yaml:"name"`
+
+	ConfigureUrl                string `split_words:"true" yaml:"configureUrl"`
+	AppID                       int64  `split_words:"true" yaml:"appID"`
+	AppInstallationID           int64  `split_words:"true" yaml:"appInstallationID"`
+	AppPrivateKey               string `split_words:"true" yaml:"appPrivateKey"`
+	Token                       string `split_words:"true" yaml:"token"`
+	EphemeralRunnerSetNamespace string `split_words:"true" yaml:"ephemeralRunnerSetNamespace"`
+	EphemeralRunnerSetName      string `split_words:"true" yaml:"ephemeralRunnerSetName"`
+	MaxRunners                  int    `split_words:"true" yaml:"maxRunners"`
+	MinRunners                  int    `split_words:"true" yaml:"minRunners"`
+	RunnerScaleSetId            int    `split_words:"true" yaml:"runnerScaleSetId"`
+	ServerRootCA                string `split_words:"true" yaml:"serverRootCA"`
+	EnablePrometheusMetrics     bool   `split_words:"true" yaml:"enablePrometheusMetrics"`
+	// PrometheusClassicHistograms keeps the job duration histograms
+	// emitting their legacy fixed buckets alongside the native (sparse)
+	// representation, for scrapers/dashboards not yet migrated.
+	PrometheusClassicHistograms bool `split_words:"true" yaml:"prometheusClassicHistograms"`
+
+	// PredictiveScaling enables forecast-aware pre-scaling: the desired
+	// runner count becomes max(reactive, forecast) instead of purely
+	// reactive to queue depth.
+	PredictiveScaling       bool          `split_words:"true" yaml:"predictiveScaling"`
+	PredictionWindow        time.Duration `split_words:"true" default:"5m" yaml:"predictionWindow"`
+	PredictionHistoryWindow int           `split_words:"true" default:"120" yaml:"predictionHistoryWindow"`
+	PredictionSeasonLength  int           `split_words:"true" default:"60" yaml:"predictionSeasonLength"`
+
+	// EnableKedaExternalScaler starts a gRPC server implementing KEDA's
+	// ExternalScaler contract so a ScaledObject of type `external` can
+	// drive scaling without polling the Kubernetes API.
+	EnableKedaExternalScaler bool   `split_words:"true" yaml:"enableKedaExternalScaler"`
+	KedaGrpcAddress          string `split_words:"true" default:":9090" yaml:"kedaGrpcAddress"`
+	KedaGrpcServerCert       string `split_words:"true" yaml:"kedaGrpcServerCert"`
+	KedaGrpcServerKey        string `split_words:"true" yaml:"kedaGrpcServerKey"`
+	// KedaGrpcClientCA is the PEM bundle of root CAs trusted to authenticate
+	// inbound KEDA gRPC clients over mTLS. It's a distinct trust domain from
+	// ServerRootCA (which is the outbound Actions Service connection's trust
+	// root) and must not be conflated with it.
+	KedaGrpcClientCA string `split_words:"true" yaml:"kedaGrpcClientCA"`
+
+	// EnableLiveStateReporter periodically writes the listener's live
+	// view of jobs/runners back to the AutoscalingRunnerSet status and
+	// serves it on /status for out-of-cluster debugging.
+	EnableLiveStateReporter    bool          `split_words:"true" yaml:"enableLiveStateReporter"`
+	LiveStateReportInterval    time.Duration `split_words:"true" default:"10s" yaml:"liveStateReportInterval"`
+	LiveStateReportMaxInterval time.Duration `split_words:"true" default:"1m" yaml:"liveStateReportMaxInterval"`
+
+	// HealthProbeBindAddress is where /livez, /readyz and /healthz are
+	// served. Leave empty to disable the health probe server.
+	HealthProbeBindAddress   string        `split_words:"true" default:":8080" yaml:"healthProbeBindAddress"`
+	MaxMessageSessionAge     time.Duration `split_words:"true" default:"5m" yaml:"maxMessageSessionAge"`
+	MaxActionsServiceSuccessAge time.Duration `split_words:"true" default:"5m" yaml:"maxActionsServiceSuccessAge"`
 }
 
 var (
@@ -71,6 +119,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	if path := configFilePath(); path != "" {
+		entries, err := loadListenerConfigFile(path)
+		if err != nil {
+			logger.Error(err, "Error: loading config file", "path", path)
+			os.Exit(1)
+		}
+
+		if err := runMulti(entries, logger); err != nil {
+			logger.Error(err, "Run error")
+			os.Exit(1)
+		}
+		return
+	}
+
 	var rc RunnerScaleSetListenerConfig
 	if err := envconfig.Process("github", &rc); err != nil {
 		logger.Error(err, "Error: processing environment variables for RunnerScaleSetListenerConfig")
@@ -94,6 +156,108 @@ func run(rc RunnerScaleSetListenerConfig, logger logr.Logger) error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Create kube manager and scale controller
+	kubeManager, err := NewKubernetesManager(&logger)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes manager: %w", err)
+	}
+
+	if rc.EnablePrometheusMetrics {
+		if err := startMetricsServer(ctx, logger, rc.PrometheusClassicHistograms); err != nil {
+			return err
+		}
+	}
+
+	startHealthProbeServer(ctx, logger, rc.HealthProbeBindAddress)
+
+	return runScaleSet(ctx, rc, kubeManager, logger)
+}
+
+// runMulti runs one goroutine per entry in entries, sharing a single
+// KubernetesManager, Prometheus registry/metrics server, and root context.
+// A failure in one scale set is restarted with backoff and does not affect
+// the others.
+func runMulti(entries []RunnerScaleSetListenerConfig, logger logr.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	kubeManager, err := NewKubernetesManager(&logger)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes manager: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.EnablePrometheusMetrics {
+			if err := startMetricsServer(ctx, logger, entry.PrometheusClassicHistograms); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if len(entries) > 0 {
+		startHealthProbeServer(ctx, logger, entries[0].HealthProbeBindAddress)
+	}
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		entry := entry
+		entryLogger := logger.WithValues("scaleSet", entry.Name)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runScaleSetWithRestart(ctx, entry, kubeManager, entryLogger)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// runScaleSetWithRestart runs a single scale set, restarting it with
+// exponential backoff (capped) if it exits with an error, until ctx is
+// cancelled.
+func runScaleSetWithRestart(ctx context.Context, rc RunnerScaleSetListenerConfig, kubeManager *KubernetesManager, logger logr.Logger) {
+	restartWithBackoff(ctx, logger, time.Second, 2*time.Minute, func() error {
+		return runScaleSet(ctx, rc, kubeManager, logger)
+	})
+}
+
+// restartWithBackoff calls run in a loop, restarting it with exponential
+// backoff (starting at initialBackoff, capped at maxBackoff) whenever it
+// returns a non-nil error, until ctx is cancelled or run returns nil. Split
+// out of runScaleSetWithRestart so the restart/backoff behavior can be
+// exercised in tests without a real scale set.
+func restartWithBackoff(ctx context.Context, logger logr.Logger, initialBackoff, maxBackoff time.Duration, run func() error) {
+	backoff := initialBackoff
+
+	for {
+		err := run()
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		logger.Error(err, "scale set exited with error, restarting", "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// runScaleSet creates and runs everything needed to service a single
+// RunnerScaleSet: the Actions Service client, message listener, scale
+// controller and (optionally) its KEDA external scaler endpoint. It returns
+// when ctx is cancelled or the message listener exits with an error.
+func runScaleSet(ctx context.Context, rc RunnerScaleSetListenerConfig, kubeManager *KubernetesManager, logger logr.Logger) error {
 	creds := &actions.ActionsAuth{}
 	if rc.Token != "" {
 		creds.Token = rc.Token
@@ -122,11 +286,7 @@ func run(rc RunnerScaleSetListenerConfig, logger logr.Logger) error {
 	}
 	defer autoScalerClient.Close()
 
-	// Create kube manager and scale controller
-	kubeManager, err := NewKubernetesManager(&logger)
-	if err != nil {
-		return fmt.Errorf("failed to create kubernetes manager: %w", err)
-	}
+	registerScaleSetHealthChecks(globalHealthChecker, rc, autoScalerClient, kubeManager, actionsServiceClient)
 
 	scaleSettings := &ScaleSettings{
 		Namespace:    rc.EphemeralRunnerSetNamespace,
@@ -135,17 +295,106 @@ func run(rc RunnerScaleSetListenerConfig, logger logr.Logger) error {
 		MinRunners:   rc.MinRunners,
 	}
 
+	prometheusLabels := prometheus.Labels{
+		"runner_scale_set_name":             strconv.Itoa(rc.RunnerScaleSetId),
+		"runner_scale_set_config_url":       rc.ConfigureUrl,
+		"auto_scaling_runner_set_name":      rc.EphemeralRunnerSetName,
+		"auto_scaling_runner_set_namespace": rc.EphemeralRunnerSetNamespace}
+
+	var scaler *predictiveScaler
+	if rc.PredictiveScaling {
+		scaler = newPredictiveScaler(logger, &rc)
+	}
+
 	service := NewService(ctx, autoScalerClient, kubeManager, scaleSettings, func(s *Service) {
 		s.logger = logger.WithName("service")
-		s.prometheusLabels = prometheus.Labels{
-			"runner_scale_set_name":             string(rc.RunnerScaleSetId),
-			"runner_scale_set_config_url":       rc.ConfigureUrl,
-			"auto_scaling_runner_set_name":      rc.EphemeralRunnerSetName,
-			"auto_scaling_runner_set_namespace": rc.EphemeralRunnerSetNamespace}
+		s.prometheusLabels = prometheusLabels
+		s.predictiveScaler = scaler
 	})
 
-	if rc.EnablePrometheusMetrics {
-		// Metrics Server
+	if scaler != nil {
+		go newPredictiveScalerDriver(scaler, service, prometheusLabels).run(ctx)
+	}
+
+	if rc.EnableKedaExternalScaler {
+		var creds credentials.TransportCredentials
+		if rc.KedaGrpcServerCert != "" {
+			creds, err = newKedaGrpcServerCredentials(rc.KedaGrpcServerCert, rc.KedaGrpcServerKey, rc.KedaGrpcClientCA)
+			if err != nil {
+				return fmt.Errorf("failed to configure KEDA external scaler TLS: %w", err)
+			}
+		}
+
+		stopKedaServer, err := startKedaExternalScalerServer(ctx, logger, rc.KedaGrpcAddress, service, creds)
+		if err != nil {
+			return fmt.Errorf("failed to start KEDA external scaler server: %w", err)
+		}
+		defer stopKedaServer()
+	}
+
+	if rc.EnableLiveStateReporter {
+		reporter := NewLiveStateReporter(logger, service, kubeManager, rc.EphemeralRunnerSetNamespace, rc.EphemeralRunnerSetName, rc.LiveStateReportInterval, rc.LiveStateReportMaxInterval)
+		go reporter.Start(ctx)
+
+		if rc.EnablePrometheusMetrics {
+			statusPath := "/status"
+			if rc.Name != "" {
+				statusPath = "/status/" + rc.Name
+			}
+			// A restarted scale set reaches here again with the same path, and
+			// http.Handle panics on a duplicate pattern, so each path is
+			// registered once and serves the latest reporter.
+			current, registered := statusReporters.LoadOrStore(statusPath, &atomic.Pointer[LiveStateReporter]{})
+			current.(*atomic.Pointer[LiveStateReporter]).Store(reporter)
+			if !registered {
+				http.Handle(statusPath, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					current.(*atomic.Pointer[LiveStateReporter]).Load().ServeHTTP(w, req)
+				}))
+			}
+		}
+	}
+
+	// Start listening for messages
+	if err = service.Start(); err != nil {
+		return fmt.Errorf("failed to start message queue listener: %w", err)
+	}
+	return nil
+}
+
+// statusReporters maps each /status path to the LiveStateReporter it serves.
+var statusReporters sync.Map
+
+// startMetricsServer registers the Prometheus collectors and starts the
+// shared exporter HTTP server that serves them until ctx is cancelled. It is
+// called at most once per process: a second call would register the same
+// collectors again, which prometheus.MustRegister rejects with a panic.
+var startMetricsServerOnce sync.Once
+
+func startMetricsServer(ctx context.Context, logger logr.Logger, classicHistogramsAlso bool) error {
+	initJobDurationHistograms(classicHistogramsAlso)
+
+	prometheus.MustRegister(
+		githubRunnerScaleSetAvailableJobs,
+		githubRunnerScaleSetAcquiredJobs,
+		githubRunnerScaleSetAssignedJobs,
+		githubRunnerScaleSetRunningJobs,
+		githubRunnerScaleSetRegisteredRunners,
+		githubRunnerScaleSetBusyRunners,
+		githubRunnerScaleSetIdleRunners,
+		githubRunnerScaleSetAcquireJobTotal,
+		githubRunnerScaleSetDesiredEphemeralRunnerPods,
+		githubRunnerScaleSetJobAvailableTotal,
+		githubRunnerScaleSetJobAssignedTotal,
+		githubRunnerScaleSetJobStartedTotal,
+		githubRunnerScaleSetJobCompletedTotal,
+		githubRunnerScaleSetJobQueueDurationSeconds,
+		githubRunnerScaleSetJobStartDurationSeconds,
+		githubRunnerScaleSetJobRunDurationSeconds,
+		githubRunnerScaleSetForecastArrivals,
+		githubRunnerScaleSetPredictiveDesiredEphemeralRunnerPods)
+
+	var startErr error
+	startMetricsServerOnce.Do(func() {
 		logger.Info("Starting prometheus exporter")
 		kingpin.Version(version.Print("arc-metrics-exporter"))
 		promlogConfig := &promlog.Config{}
@@ -154,25 +403,13 @@ func run(rc RunnerScaleSetListenerConfig, logger logr.Logger) error {
 		kingpin.Parse()
 		promLogger := promlog.New(promlogConfig)
 
-		prometheus.MustRegister(
-			githubRunnerScaleSetAvailableJobs,
-			githubRunnerScaleSetAcquiredJobs,
-			githubRunnerScaleSetAssignedJobs,
-			githubRunnerScaleSetRunningJobs,
-			githubRunnerScaleSetRegisteredRunners,
-			githubRunnerScaleSetBusyRunners,
-			githubRunnerScaleSetIdleRunners,
-			githubRunnerScaleSetAcquireJobTotal,
-			githubRunnerScaleSetDesiredEphemeralRunnerPods,
-			githubRunnerScaleSetJobAvailableTotal,
-			githubRunnerScaleSetJobAssignedTotal,
-			githubRunnerScaleSetJobStartedTotal,
-			githubRunnerScaleSetJobCompletedTotal,
-			githubRunnerScaleSetJobQueueDurationSeconds,
-			githubRunnerScaleSetJobStartDurationSeconds,
-			githubRunnerScaleSetJobRunDurationSeconds)
-
-		http.Handle(*metricsPath, promhttp.Handler())
+		// promhttp picks the exposition format from the scraper's Accept
+		// header; scrapers that request the protobuf format get the native
+		// (sparse) histograms, which the text formats can't carry.
+		// EnableOpenMetrics additionally offers the OpenMetrics text format.
+		http.Handle(*metricsPath, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: true,
+		}))
 		landingConfig := web.LandingConfig{
 			Name:        "Actions-Runner-Controller Exporter",
 			Description: "Actions-Runner-Controller Exporter",
@@ -186,8 +423,8 @@ func run(rc RunnerScaleSetListenerConfig, logger logr.Logger) error {
 		}
 		landingPage, err := web.NewLandingPage(landingConfig)
 		if err != nil {
-			level.Error(promLogger).Log("err", err)
-			os.Exit(1)
+			startErr = fmt.Errorf("failed to create metrics landing page: %w", err)
+			return
 		}
 		http.Handle("/", landingPage)
 
@@ -200,17 +437,55 @@ func run(rc RunnerScaleSetListenerConfig, logger logr.Logger) error {
 
 			if err := web.ListenAndServe(metricsServer, webConfig, promLogger); err != nil {
 				if !errors.Is(err, http.ErrServerClosed) {
-					logger.Error(err, "problem running metrics server")
+					level.Error(promLogger).Log("err", err, "msg", "problem running metrics server")
 				}
 			}
 		}()
+	})
+
+	return startErr
+}
+
+var (
+	globalHealthChecker       = NewHealthChecker()
+	startHealthProbeServerOnce sync.Once
+)
+
+// startHealthProbeServer starts the always-on /livez, /readyz, /healthz
+// server the first time it's called (subsequent calls, e.g. one per scale
+// set in multi mode, reuse the same server and share globalHealthChecker).
+func startHealthProbeServer(ctx context.Context, logger logr.Logger, addr string) {
+	if addr == "" {
+		return
 	}
 
-	// Start listening for messages
-	if err = service.Start(); err != nil {
-		return fmt.Errorf("failed to start message queue listener: %w", err)
+	startHealthProbeServerOnce.Do(func() {
+		server := &http.Server{
+			Addr:    addr,
+			Handler: newHealthProbeHandler(logger, globalHealthChecker),
+		}
+
+		go func() {
+			<-ctx.Done()
+			server.Shutdown(context.Background())
+		}()
+
+		go func() {
+			logger.Info("Starting health probe server", "address", addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error(err, "problem running health probe server")
+			}
+		}()
+	})
+}
+
+// checkName namespaces a health check's key by scale set name when running
+// in multi scale set mode, so /readyz?verbose=1 can tell them apart.
+func checkName(rc RunnerScaleSetListenerConfig, check string) string {
+	if rc.Name == "" {
+		return check
 	}
-	return nil
+	return rc.Name + ":" + check
 }
 
 func validateConfig(config *RunnerScaleSetListenerConfig) error {
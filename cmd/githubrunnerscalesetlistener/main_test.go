@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestRestartWithBackoffRestartsOnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	var gaps []time.Duration
+	last := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		restartWithBackoff(ctx, logr.Discard(), time.Millisecond, 8*time.Millisecond, func() error {
+			now := time.Now()
+			gaps = append(gaps, now.Sub(last))
+			last = now
+
+			n := atomic.AddInt32(&calls, 1)
+			if n >= 5 {
+				cancel()
+			}
+			return errors.New("boom")
+		})
+	}()
+
+	<-done
+
+	if got := atomic.LoadInt32(&calls); got < 5 {
+		t.Fatalf("expected at least 5 restarts before cancellation, got %d", got)
+	}
+
+	// Skip the first gap: it's the time to the first call, not a backoff.
+	for i := 2; i < len(gaps) && i < 4; i++ {
+		if gaps[i] < gaps[i-1] {
+			t.Fatalf("expected non-decreasing backoff, gap[%d]=%v < gap[%d]=%v", i, gaps[i], i-1, gaps[i-1])
+		}
+	}
+}
+
+func TestRestartWithBackoffStopsOnSuccess(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int32
+	restartWithBackoff(ctx, logr.Discard(), time.Millisecond, time.Millisecond, func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected run to be called exactly once before returning nil, got %d", got)
+	}
+}
+
+func TestRestartWithBackoffIsolatesFailures(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var flakyCalls, alwaysFailCalls int32
+	flakyDone := make(chan struct{})
+	alwaysFailDone := make(chan struct{})
+
+	// flaky fails once then succeeds; its failure and restart must not
+	// affect the always-failing sibling running concurrently.
+	go func() {
+		defer close(flakyDone)
+		restartWithBackoff(ctx, logr.Discard(), time.Millisecond, time.Millisecond, func() error {
+			if atomic.AddInt32(&flakyCalls, 1) == 1 {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	}()
+
+	go func() {
+		defer close(alwaysFailDone)
+		restartWithBackoff(ctx, logr.Discard(), time.Millisecond, time.Millisecond, func() error {
+			atomic.AddInt32(&alwaysFailCalls, 1)
+			return errors.New("boom")
+		})
+	}()
+
+	<-flakyDone
+
+	if got := atomic.LoadInt32(&flakyCalls); got != 2 {
+		t.Fatalf("expected flaky run to be called exactly twice, got %d", got)
+	}
+
+	// Give the always-failing sibling a chance to keep restarting on its
+	// own, independent of the flaky one having already returned.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-alwaysFailDone
+
+	if got := atomic.LoadInt32(&alwaysFailCalls); got < 2 {
+		t.Fatalf("expected always-failing sibling to keep restarting independently, got %d calls", got)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// nativeHistogramBucketFactor controls the resolution of the sparse
+	// native histogram representation; 1.1 means adjacent buckets differ
+	// by at most 10%.
+	nativeHistogramBucketFactor = 1.1
+	// nativeHistogramMaxBucketNumber bounds the number of sparse buckets
+	// per series; past it, the histogram is reset or its resolution is
+	// reduced by merging adjacent buckets.
+	nativeHistogramMaxBucketNumber = 160
+	// nativeHistogramMinResetDuration is the minimum time between resets
+	// of the bucket count, to avoid a burst of cardinality growth from
+	// repeatedly hitting nativeHistogramMaxBucketNumber.
+	nativeHistogramMinResetDuration = 1 * time.Hour
+)
+
+// scaleSetLabels are the labels attached to every metric emitted by a single
+// listener so that series from different AutoscalingRunnerSets can share one
+// registry without colliding.
+var scaleSetLabels = []string{
+	"runner_scale_set_name",
+	"runner_scale_set_config_url",
+	"auto_scaling_runner_set_name",
+	"auto_scaling_runner_set_namespace",
+}
+
+var (
+	githubRunnerScaleSetAvailableJobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_available_jobs",
+		Help: "Number of jobs available for the scale set.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetAcquiredJobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_acquired_jobs",
+		Help: "Number of jobs acquired by the scale set.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetAssignedJobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_assigned_jobs",
+		Help: "Number of jobs assigned to the scale set.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetRunningJobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_running_jobs",
+		Help: "Number of jobs running on the scale set.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetRegisteredRunners = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_registered_runners",
+		Help: "Number of runners registered for the scale set.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetBusyRunners = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_busy_runners",
+		Help: "Number of registered runners currently running a job.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetIdleRunners = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_idle_runners",
+		Help: "Number of registered runners currently idle.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetAcquireJobTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_runner_scale_set_acquire_job_total",
+		Help: "Total number of jobs acquired by the scale set.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetDesiredEphemeralRunnerPods = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_desired_ephemeral_runner_pods",
+		Help: "Desired number of ephemeral runner pods, as computed by the scale controller.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetJobAvailableTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_runner_scale_set_job_available_total",
+		Help: "Total number of job available messages received.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetJobAssignedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_runner_scale_set_job_assigned_total",
+		Help: "Total number of job assigned messages received.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetJobStartedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_runner_scale_set_job_started_total",
+		Help: "Total number of job started messages received.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetJobCompletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_runner_scale_set_job_completed_total",
+		Help: "Total number of job completed messages received.",
+	}, scaleSetLabels)
+
+	// githubRunnerScaleSetJobQueueDurationSeconds, githubRunnerScaleSetJobStartDurationSeconds
+	// and githubRunnerScaleSetJobRunDurationSeconds start out native-only,
+	// same as every other metric in this file, so job-lifecycle code can
+	// safely observe them even when Prometheus metrics are disabled and
+	// initJobDurationHistograms never runs. initJobDurationHistograms only
+	// reconfigures them to also carry classic buckets, when requested.
+	githubRunnerScaleSetJobQueueDurationSeconds = newDurationHistogram(
+		"github_runner_scale_set_job_queue_duration_seconds",
+		"Time a job spent waiting to be assigned a runner, from job available to job assigned.",
+		false,
+	)
+	githubRunnerScaleSetJobStartDurationSeconds = newDurationHistogram(
+		"github_runner_scale_set_job_start_duration_seconds",
+		"Time a job spent waiting for its runner to start, from job assigned to job started.",
+		false,
+	)
+	githubRunnerScaleSetJobRunDurationSeconds = newDurationHistogram(
+		"github_runner_scale_set_job_run_duration_seconds",
+		"Time a job spent running, from job started to job completed.",
+		false,
+	)
+
+	githubRunnerScaleSetForecastArrivals = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_forecast_arrivals",
+		Help: "Forecasted number of job arrivals over the next prediction window.",
+	}, scaleSetLabels)
+
+	githubRunnerScaleSetPredictiveDesiredEphemeralRunnerPods = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "github_runner_scale_set_predictive_desired_ephemeral_runner_pods",
+		Help: "Desired number of ephemeral runner pods after combining the reactive and forecast signals.",
+	}, scaleSetLabels)
+)
+
+// newDurationHistogram builds a job duration histogram as a native (sparse)
+// histogram. When classicBucketsAlso is set, it also keeps emitting the
+// legacy fixed buckets alongside the native representation so existing
+// dashboards built on them keep working while scrapers are migrated.
+func newDurationHistogram(name, help string, classicBucketsAlso bool) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{
+		Name:                            name,
+		Help:                            help,
+		NativeHistogramBucketFactor:     nativeHistogramBucketFactor,
+		NativeHistogramMaxBucketNumber:  nativeHistogramMaxBucketNumber,
+		NativeHistogramMinResetDuration: nativeHistogramMinResetDuration,
+	}
+	if classicBucketsAlso {
+		opts.Buckets = prometheus.DefBuckets
+	}
+	return prometheus.NewHistogramVec(opts, scaleSetLabels)
+}
+
+// initJobDurationHistograms reconfigures the job duration histograms,
+// rebuilding them with classic (fixed-bucket) observations alongside the
+// native representation when classicBucketsAlso is set. The vars are
+// already non-nil, native-only histograms from package init, so code that
+// observes them is safe to call whether or not this ever runs (e.g. when
+// Prometheus metrics are disabled); this only needs to run when the
+// default needs reconfiguring.
+//
+// Must be called before the histograms are registered and before any
+// observations are recorded.
+func initJobDurationHistograms(classicBucketsAlso bool) {
+	githubRunnerScaleSetJobQueueDurationSeconds = newDurationHistogram(
+		"github_runner_scale_set_job_queue_duration_seconds",
+		"Time a job spent waiting to be assigned a runner, from job available to job assigned.",
+		classicBucketsAlso,
+	)
+	githubRunnerScaleSetJobStartDurationSeconds = newDurationHistogram(
+		"github_runner_scale_set_job_start_duration_seconds",
+		"Time a job spent waiting for its runner to start, from job assigned to job started.",
+		classicBucketsAlso,
+	)
+	githubRunnerScaleSetJobRunDurationSeconds = newDurationHistogram(
+		"github_runner_scale_set_job_run_duration_seconds",
+		"Time a job spent running, from job started to job completed.",
+		classicBucketsAlso,
+	)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestInitJobDurationHistogramsNativeOnly(t *testing.T) {
+	initJobDurationHistograms(false)
+
+	githubRunnerScaleSetJobQueueDurationSeconds.WithLabelValues("a", "b", "c", "d").Observe(1.5)
+
+	m := collectSingleMetric(t, githubRunnerScaleSetJobQueueDurationSeconds)
+	if m.Histogram.GetSchema() == 0 && m.Histogram.GetZeroThreshold() == 0 && len(m.Histogram.GetBucket()) > 0 {
+		t.Fatalf("expected a native histogram, got classic buckets: %v", m.Histogram)
+	}
+	if len(m.Histogram.GetBucket()) != 0 {
+		t.Fatalf("expected no classic buckets when PrometheusClassicHistograms is disabled, got %d", len(m.Histogram.GetBucket()))
+	}
+}
+
+func TestInitJobDurationHistogramsClassicAlso(t *testing.T) {
+	initJobDurationHistograms(true)
+
+	githubRunnerScaleSetJobStartDurationSeconds.WithLabelValues("a", "b", "c", "d").Observe(1.5)
+
+	m := collectSingleMetric(t, githubRunnerScaleSetJobStartDurationSeconds)
+	if len(m.Histogram.GetBucket()) == 0 {
+		t.Fatalf("expected classic buckets to also be populated when PrometheusClassicHistograms is enabled")
+	}
+}
+
+func collectSingleMetric(t *testing.T, c prometheus.Collector) *dto.Metric {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1)
+	c.Collect(ch)
+	close(ch)
+
+	var pb dto.Metric
+	for metric := range ch {
+		if err := metric.Write(&pb); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+		return &pb
+	}
+	t.Fatal("expected at least one collected metric")
+	return nil
+}
+
+func TestJobDurationHistogramNames(t *testing.T) {
+	initJobDurationHistograms(false)
+
+	for _, h := range []*prometheus.HistogramVec{
+		githubRunnerScaleSetJobQueueDurationSeconds,
+		githubRunnerScaleSetJobStartDurationSeconds,
+		githubRunnerScaleSetJobRunDurationSeconds,
+	} {
+		desc := h.WithLabelValues("a", "b", "c", "d").Desc().String()
+		if !strings.Contains(desc, "github_runner_scale_set_job_") {
+			t.Fatalf("unexpected histogram name in desc: %s", desc)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// defaultSeasonLength is the number of one-minute buckets that make up a
+// single season (1 hour) when no explicit PredictionSeasonLength is set.
+const defaultSeasonLength = 60
+
+// arrivalRingBuffer is a fixed-size, overwrite-oldest ring buffer of
+// per-minute job arrival counts used as the input series for forecasting.
+type arrivalRingBuffer struct {
+	mu     sync.Mutex
+	counts []float64
+	next   int
+	filled bool
+}
+
+func newArrivalRingBuffer(size int) *arrivalRingBuffer {
+	return &arrivalRingBuffer{counts: make([]float64, size)}
+}
+
+// record adds a single arrival to the current (most recent) bucket.
+func (b *arrivalRingBuffer) record() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	idx := b.next
+	if idx == 0 {
+		idx = len(b.counts) - 1
+	} else {
+		idx--
+	}
+	b.counts[idx]++
+}
+
+// advance rotates the ring so that a new, empty bucket becomes current. It
+// should be called once per bucket interval (e.g. once a minute).
+func (b *arrivalRingBuffer) advance() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.counts[b.next] = 0
+	b.next = (b.next + 1) % len(b.counts)
+	if b.next == 0 {
+		b.filled = true
+	}
+}
+
+// series returns the buckets in chronological order, oldest first. Until the
+// ring first wraps around, only closed buckets are returned (the first one
+// lives in the last slot, see record) and the open current bucket is left
+// out; once it has wrapped, every slot is returned and the last entry is the
+// open current bucket.
+func (b *arrivalRingBuffer) series() []float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.filled {
+		out := make([]float64, 0, b.next)
+		if b.next > 0 {
+			out = append(out, b.counts[len(b.counts)-1])
+			out = append(out, b.counts[:b.next-1]...)
+		}
+		return out
+	}
+	out := make([]float64, len(b.counts))
+	copy(out, b.counts[b.next:])
+	copy(out[len(b.counts)-b.next:], b.counts[:b.next])
+	return out
+}
+
+// holtWinters is an additive-seasonal triple exponential smoother used to
+// forecast job arrivals a few minutes ahead of the current reactive signal.
+type holtWinters struct {
+	alpha, beta, gamma float64
+	seasonLength       int
+
+	level    float64
+	trend    float64
+	seasonal []float64
+	bootstrapped bool
+}
+
+func newHoltWinters(alpha, beta, gamma float64, seasonLength int) *holtWinters {
+	return &holtWinters{
+		alpha:        alpha,
+		beta:         beta,
+		gamma:        gamma,
+		seasonLength: seasonLength,
+	}
+}
+
+// fit (re)computes level, trend and seasonal indices from a chronological
+// series of observations. Until at least 2*seasonLength observations are
+// available it falls back to a plain moving-average forecast and reports
+// bootstrapped=false.
+func (hw *holtWinters) fit(series []float64) {
+	m := hw.seasonLength
+	if len(series) < 2*m {
+		hw.bootstrapped = false
+		return
+	}
+
+	// Bootstrap seasonal indices from the first two full seasons.
+	firstAvg := average(series[0:m])
+	secondAvg := average(series[m : 2*m])
+
+	seasonal := make([]float64, m)
+	for i := 0; i < m; i++ {
+		seasonal[i] = (series[i]-firstAvg + series[m+i]-secondAvg) / 2
+	}
+
+	level := firstAvg
+	trend := (secondAvg - firstAvg) / float64(m)
+
+	for t := 2 * m; t < len(series); t++ {
+		y := series[t]
+		s := seasonal[t%m]
+
+		prevLevel := level
+		level = hw.alpha*(y-s) + (1-hw.alpha)*(prevLevel+trend)
+		trend = hw.beta*(level-prevLevel) + (1-hw.beta)*trend
+		seasonal[t%m] = hw.gamma*(y-level) + (1-hw.gamma)*s
+	}
+
+	hw.level = level
+	hw.trend = trend
+	hw.seasonal = seasonal
+	hw.bootstrapped = true
+}
+
+// forecast predicts the sum of arrivals over the next h buckets. When the
+// seasonal indices haven't bootstrapped yet, it falls back to a flat
+// moving-average projection over the observed series.
+func (hw *holtWinters) forecast(series []float64, h int) float64 {
+	if !hw.bootstrapped {
+		if len(series) == 0 {
+			return 0
+		}
+		window := series
+		if len(window) > defaultSeasonLength {
+			window = window[len(window)-defaultSeasonLength:]
+		}
+		return average(window) * float64(h)
+	}
+
+	m := hw.seasonLength
+	var total float64
+	for step := 1; step <= h; step++ {
+		idx := (len(series) + step - 1) % m
+		total += hw.level + float64(step)*hw.trend + hw.seasonal[idx]
+	}
+	if total < 0 {
+		return 0
+	}
+	return total
+}
+
+func average(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// predictiveScaler combines the reactive desired-count computation with a
+// short-horizon forecast of job arrivals to pre-warm runners ahead of
+// predictable load spikes.
+type predictiveScaler struct {
+	logger logr.Logger
+
+	arrivals *arrivalRingBuffer
+	model    *holtWinters
+
+	predictionWindow time.Duration
+	bucketInterval   time.Duration
+
+	minRunners int
+	maxRunners int
+}
+
+func newPredictiveScaler(logger logr.Logger, cfg *RunnerScaleSetListenerConfig) *predictiveScaler {
+	seasonLength := cfg.PredictionSeasonLength
+	if seasonLength <= 0 {
+		seasonLength = defaultSeasonLength
+	}
+
+	historyBuckets := cfg.PredictionHistoryWindow
+	if historyBuckets <= 0 {
+		historyBuckets = 2 * seasonLength
+	}
+
+	return &predictiveScaler{
+		logger:           logger.WithName("predictive-scaler"),
+		arrivals:         newArrivalRingBuffer(historyBuckets),
+		model:            newHoltWinters(0.3, 0.1, 0.3, seasonLength),
+		predictionWindow: cfg.PredictionWindow,
+		bucketInterval:   time.Minute,
+		minRunners:       cfg.MinRunners,
+		maxRunners:       cfg.MaxRunners,
+	}
+}
+
+// recordJobAvailable should be called whenever a job-available message is
+// observed, feeding the rolling arrival series.
+func (p *predictiveScaler) recordJobAvailable() {
+	p.arrivals.record()
+}
+
+// tick rotates the ring buffer to a new bucket. Call once per bucketInterval.
+func (p *predictiveScaler) tick() {
+	p.arrivals.advance()
+}
+
+// forecastArrivals fits the model against the current arrival history and
+// returns the forecasted number of job arrivals over the next prediction
+// window.
+func (p *predictiveScaler) forecastArrivals() float64 {
+	series := p.arrivals.series()
+	p.model.fit(series)
+
+	buckets := p.predictionWindow.Minutes()
+	if buckets <= 0 {
+		buckets = 5
+	}
+	return p.model.forecast(series, int(math.Round(buckets)))
+}
+
+// desiredRunners returns max(reactiveDesired, forecastDesired), clamped to
+// [minRunners, maxRunners].
+func (p *predictiveScaler) desiredRunners(reactiveDesired int, avgJobDurationSeconds float64) int {
+	return p.desiredRunnersForForecast(reactiveDesired, p.forecastArrivals(), avgJobDurationSeconds)
+}
+
+// desiredRunnersForForecast is desiredRunners for a forecast already
+// computed by forecastArrivals, so callers that also publish the forecast
+// itself (see predictiveScalerDriver) don't have to fit the model twice.
+func (p *predictiveScaler) desiredRunnersForForecast(reactiveDesired int, forecastArrivals, avgJobDurationSeconds float64) int {
+	windowSeconds := p.predictionWindow.Seconds()
+	if windowSeconds <= 0 {
+		windowSeconds = 5 * 60
+	}
+
+	forecastDesired := int(math.Ceil(forecastArrivals * avgJobDurationSeconds / windowSeconds))
+
+	desired := reactiveDesired
+	if forecastDesired > desired {
+		desired = forecastDesired
+	}
+
+	if desired < p.minRunners {
+		desired = p.minRunners
+	}
+	if desired > p.maxRunners {
+		desired = p.maxRunners
+	}
+
+	return desired
+}
+
+// jobArrivalState is the subset of the Service's live state the predictive
+// scaler needs: a wakeup signal for newly arrived jobs, and the reactive
+// signals (queue depth, busy runners, recent average job duration) the
+// forecast is blended with. Service implements this the same way it
+// implements jobQueueState.
+type jobArrivalState interface {
+	QueuedJobs() int
+	BusyRunners() int
+	AverageJobDurationSeconds() float64
+	Subscribe() (ch <-chan struct{}, unsubscribe func())
+}
+
+// predictiveScalerDriver feeds a predictiveScaler from a running Service's
+// live queue state and publishes its output as Prometheus gauges. Arrivals
+// are counted off githubRunnerScaleSetJobAvailableTotal, the monotonic
+// counter incremented wherever job-available messages are handled, rather
+// than off QueuedJobs(): QueuedJobs is a point-in-time gauge sampled each
+// time state's subscription channel fires, so arrivals that are dequeued
+// again before the next notification would be undercounted under bursty
+// load. A ticker rotates the arrival buckets once per bucketInterval
+// regardless of whether any state change fired.
+type predictiveScalerDriver struct {
+	scaler *predictiveScaler
+	state  jobArrivalState
+	labels prometheus.Labels
+}
+
+func newPredictiveScalerDriver(scaler *predictiveScaler, state jobArrivalState, labels prometheus.Labels) *predictiveScalerDriver {
+	return &predictiveScalerDriver{scaler: scaler, state: state, labels: labels}
+}
+
+// run observes job arrivals and publishes the forecast/desired Prometheus
+// gauges until ctx is cancelled.
+func (d *predictiveScalerDriver) run(ctx context.Context) {
+	ch, unsubscribe := d.state.Subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(d.scaler.bucketInterval)
+	defer ticker.Stop()
+
+	lastJobAvailable := d.jobAvailableTotal()
+	d.publish()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.scaler.tick()
+			d.publish()
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+			lastJobAvailable = d.recordArrivals(lastJobAvailable)
+			d.publish()
+		}
+	}
+}
+
+// jobAvailableTotal reads the current value of
+// githubRunnerScaleSetJobAvailableTotal for this driver's labels.
+func (d *predictiveScalerDriver) jobAvailableTotal() float64 {
+	return counterValue(githubRunnerScaleSetJobAvailableTotal.With(d.labels))
+}
+
+// recordArrivals records one job arrival with the predictive scaler for
+// every increase in githubRunnerScaleSetJobAvailableTotal since
+// lastJobAvailable, and returns the new baseline to diff the next
+// observation against. Split out from run so it can be exercised
+// deterministically in tests.
+func (d *predictiveScalerDriver) recordArrivals(lastJobAvailable float64) float64 {
+	current := d.jobAvailableTotal()
+	delta := int(math.Round(current - lastJobAvailable))
+	for i := 0; i < delta; i++ {
+		d.scaler.recordJobAvailable()
+	}
+	return current
+}
+
+// counterValue returns a Prometheus counter's current value. Diffing this
+// monotonic total catches every increment, unlike diffing a gauge sampled
+// only when a notification fires.
+func counterValue(c prometheus.Counter) float64 {
+	var pb dto.Metric
+	if err := c.Write(&pb); err != nil {
+		return 0
+	}
+	return pb.GetCounter().GetValue()
+}
+
+// publish recomputes the forecast and blended desired runner count and sets
+// the corresponding Prometheus gauges.
+func (d *predictiveScalerDriver) publish() {
+	forecast := d.scaler.forecastArrivals()
+	reactiveDesired := d.state.QueuedJobs() + d.state.BusyRunners()
+	desired := d.scaler.desiredRunnersForForecast(reactiveDesired, forecast, d.state.AverageJobDurationSeconds())
+
+	githubRunnerScaleSetForecastArrivals.With(d.labels).Set(forecast)
+	githubRunnerScaleSetPredictiveDesiredEphemeralRunnerPods.With(d.labels).Set(float64(desired))
+}
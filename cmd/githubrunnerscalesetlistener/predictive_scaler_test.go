@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestArrivalRingBufferWithoutWraparound(t *testing.T) {
+	b := newArrivalRingBuffer(4)
+	b.record()
+	b.record() // bucket 0 (oldest): 2 arrivals
+	b.advance()
+	b.record() // bucket 1: 1 arrival
+	b.advance()
+	// bucket 2 (current, still open): 0 arrivals so far
+
+	series := b.series()
+	if len(series) != 2 {
+		t.Fatalf("expected 2 closed buckets (the still-open current bucket isn't included), got %d: %v", len(series), series)
+	}
+	if series[0] != 2 || series[1] != 1 {
+		t.Fatalf("unexpected series: %v, want [2 1]", series)
+	}
+}
+
+func TestArrivalRingBufferEvictsOldestOnWraparound(t *testing.T) {
+	// A 3-slot buffer holds at most 2 closed buckets plus the current one
+	// being recorded into; a 3rd advance() wraps around and evicts the
+	// oldest bucket's count to make room for a new current bucket.
+	b := newArrivalRingBuffer(3)
+	b.record()
+	b.record() // bucket 0 (oldest, evicted below): 2 arrivals
+	b.advance()
+	b.record() // bucket 1: 1 arrival
+	b.advance()
+	b.advance() // wraps around: bucket 0's count of 2 is evicted
+
+	series := b.series()
+	if len(series) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(series))
+	}
+	if series[0] != 1 || series[1] != 0 || series[2] != 0 {
+		t.Fatalf("unexpected series: %v, want [1 0 0]", series)
+	}
+}
+
+func TestHoltWintersFallsBackToMovingAverageBeforeBootstrap(t *testing.T) {
+	hw := newHoltWinters(0.3, 0.1, 0.3, 4)
+	series := []float64{1, 2, 3, 4, 5}
+	hw.fit(series)
+
+	if hw.bootstrapped {
+		t.Fatalf("expected model to not be bootstrapped with only %d observations", len(series))
+	}
+
+	got := hw.forecast(series, 2)
+	want := average(series) * 2
+	if math.Abs(got-want) > 1e-9 {
+		t.Fatalf("forecast() = %v, want %v", got, want)
+	}
+}
+
+func TestHoltWintersForecastsSeasonalPattern(t *testing.T) {
+	const season = 4
+	hw := newHoltWinters(0.5, 0.3, 0.5, season)
+
+	// A perfectly repeating seasonal pattern with no trend.
+	pattern := []float64{1, 5, 1, 5}
+	var series []float64
+	for i := 0; i < 6; i++ {
+		series = append(series, pattern...)
+	}
+
+	hw.fit(series)
+	if !hw.bootstrapped {
+		t.Fatalf("expected model to bootstrap with %d observations", len(series))
+	}
+
+	got := hw.forecast(series, season)
+	want := pattern[0] + pattern[1] + pattern[2] + pattern[3]
+	if math.Abs(got-want) > 1.0 {
+		t.Fatalf("forecast() = %v, want ~%v", got, want)
+	}
+}
+
+func TestPredictiveScalerDesiredRunnersClamped(t *testing.T) {
+	cfg := &RunnerScaleSetListenerConfig{
+		MinRunners:              1,
+		MaxRunners:              5,
+		PredictionWindow:        5 * time.Minute,
+		PredictionHistoryWindow: 8,
+		PredictionSeasonLength:  4,
+	}
+	p := newPredictiveScaler(logr.Discard(), cfg)
+
+	for i := 0; i < 20; i++ {
+		p.recordJobAvailable()
+		p.recordJobAvailable()
+		p.recordJobAvailable()
+		p.tick()
+	}
+
+	desired := p.desiredRunners(0, 60)
+	if desired < cfg.MinRunners || desired > cfg.MaxRunners {
+		t.Fatalf("desiredRunners() = %d, want within [%d,%d]", desired, cfg.MinRunners, cfg.MaxRunners)
+	}
+
+	// The reactive value should win when it exceeds the forecast.
+	desired = p.desiredRunners(cfg.MaxRunners, 60)
+	if desired != cfg.MaxRunners {
+		t.Fatalf("desiredRunners() = %d, want %d (reactive should dominate)", desired, cfg.MaxRunners)
+	}
+}
+
+// fakeJobArrivalState is a test double for jobArrivalState; QueuedJobs,
+// BusyRunners and AverageJobDurationSeconds are driven directly by the test.
+type fakeJobArrivalState struct {
+	queuedJobs  int
+	busyRunners int
+	avgDuration float64
+}
+
+func (f *fakeJobArrivalState) QueuedJobs() int                   { return f.queuedJobs }
+func (f *fakeJobArrivalState) BusyRunners() int                  { return f.busyRunners }
+func (f *fakeJobArrivalState) AverageJobDurationSeconds() float64 { return f.avgDuration }
+func (f *fakeJobArrivalState) Subscribe() (<-chan struct{}, func()) {
+	return make(chan struct{}), func() {}
+}
+
+// testLabels returns a label set for scaleSetName, unique per caller so
+// tests that drive githubRunnerScaleSetJobAvailableTotal don't see each
+// other's counter increments.
+func testLabels(scaleSetName string) prometheus.Labels {
+	return prometheus.Labels{
+		"runner_scale_set_name":             scaleSetName,
+		"runner_scale_set_config_url":       "https://github.com/org/repo",
+		"auto_scaling_runner_set_name":      "ers",
+		"auto_scaling_runner_set_namespace": "ns",
+	}
+}
+
+func TestPredictiveScalerDriverRecordsArrivalsOnQueueIncrease(t *testing.T) {
+	cfg := &RunnerScaleSetListenerConfig{
+		MinRunners:              0,
+		MaxRunners:              10,
+		PredictionWindow:        5 * time.Minute,
+		PredictionHistoryWindow: 4,
+		PredictionSeasonLength:  2,
+	}
+	scaler := newPredictiveScaler(logr.Discard(), cfg)
+	state := &fakeJobArrivalState{}
+	labels := testLabels("records-arrivals")
+	driver := newPredictiveScalerDriver(scaler, state, labels)
+
+	jobAvailable := githubRunnerScaleSetJobAvailableTotal.With(labels)
+	jobAvailable.Add(3)
+	lastJobAvailable := driver.recordArrivals(0)
+	if lastJobAvailable != 3 {
+		t.Fatalf("recordArrivals() = %v, want 3", lastJobAvailable)
+	}
+
+	scaler.tick()
+	series := scaler.arrivals.series()
+	if len(series) != 1 || series[0] != 3 {
+		t.Fatalf("unexpected arrival series after recording 3 jobs: %v, want [3]", series)
+	}
+
+	// A second call with no further increments records nothing new.
+	lastJobAvailable = driver.recordArrivals(lastJobAvailable)
+	if lastJobAvailable != 3 {
+		t.Fatalf("recordArrivals() = %v, want 3 (unchanged)", lastJobAvailable)
+	}
+	scaler.tick()
+	series = scaler.arrivals.series()
+	if series[1] != 0 {
+		t.Fatalf("unexpected arrival series after an unchanged observation: %v, want second bucket 0", series)
+	}
+}
+
+func TestPredictiveScalerDriverPublishSetsGauges(t *testing.T) {
+	cfg := &RunnerScaleSetListenerConfig{
+		MinRunners:              1,
+		MaxRunners:              5,
+		PredictionWindow:        5 * time.Minute,
+		PredictionHistoryWindow: 4,
+		PredictionSeasonLength:  2,
+	}
+	scaler := newPredictiveScaler(logr.Discard(), cfg)
+	state := &fakeJobArrivalState{busyRunners: 2}
+	labels := testLabels("publish-sets-gauges")
+	driver := newPredictiveScalerDriver(scaler, state, labels)
+
+	driver.publish()
+
+	desired := testutil.ToFloat64(githubRunnerScaleSetPredictiveDesiredEphemeralRunnerPods.With(labels))
+	if desired < float64(cfg.MinRunners) || desired > float64(cfg.MaxRunners) {
+		t.Fatalf("predictive desired gauge = %v, want within [%d,%d]", desired, cfg.MinRunners, cfg.MaxRunners)
+	}
+
+	forecast := testutil.ToFloat64(githubRunnerScaleSetForecastArrivals.With(labels))
+	if forecast != 0 {
+		t.Fatalf("forecast gauge = %v, want 0 before any arrivals are recorded", forecast)
+	}
+}
@@ -0,0 +1,77 @@
+// Hand-maintained stand-in for the protoc-gen-go output of
+// externalscaler.proto: the message types and their minimal proto.Message
+// methods, kept in sync with the .proto by hand since this tree has no
+// protoc/buf toolchain wired up. It deliberately does not carry a "Code
+// generated... DO NOT EDIT" banner so it isn't mistaken for real generated
+// code (which would also include a file descriptor, Get* accessors and
+// protoimpl plumbing) and overwritten by a future protoc run without
+// noticing the difference. See generate.go for the go:generate line that
+// replaces this file and the reasoning for keeping it hand-maintained
+// until then.
+
+package keda
+
+import (
+	fmt "fmt"
+)
+
+type ScaledObjectRef struct {
+	Name           string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Namespace      string            `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ScalerMetadata map[string]string `protobuf:"bytes,3,rep,name=scalerMetadata,proto3" json:"scalerMetadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *ScaledObjectRef) Reset()         { *m = ScaledObjectRef{} }
+func (m *ScaledObjectRef) String() string { return fmt.Sprintf("%+v", *m) }
+func (*ScaledObjectRef) ProtoMessage()    {}
+
+type IsActiveResponse struct {
+	Result bool `protobuf:"varint,1,opt,name=result,proto3" json:"result,omitempty"`
+}
+
+func (m *IsActiveResponse) Reset()         { *m = IsActiveResponse{} }
+func (m *IsActiveResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*IsActiveResponse) ProtoMessage()    {}
+
+type MetricSpec struct {
+	MetricName string `protobuf:"bytes,1,opt,name=metricName,proto3" json:"metricName,omitempty"`
+	TargetSize int64  `protobuf:"varint,2,opt,name=targetSize,proto3" json:"targetSize,omitempty"`
+}
+
+func (m *MetricSpec) Reset()         { *m = MetricSpec{} }
+func (m *MetricSpec) String() string { return fmt.Sprintf("%+v", *m) }
+func (*MetricSpec) ProtoMessage()    {}
+
+type GetMetricSpecResponse struct {
+	MetricSpecs []*MetricSpec `protobuf:"bytes,1,rep,name=metricSpecs,proto3" json:"metricSpecs,omitempty"`
+}
+
+func (m *GetMetricSpecResponse) Reset()         { *m = GetMetricSpecResponse{} }
+func (m *GetMetricSpecResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*GetMetricSpecResponse) ProtoMessage()    {}
+
+type GetMetricsRequest struct {
+	ScaledObjectRef *ScaledObjectRef `protobuf:"bytes,1,opt,name=scaledObjectRef,proto3" json:"scaledObjectRef,omitempty"`
+	MetricName      string           `protobuf:"bytes,2,opt,name=metricName,proto3" json:"metricName,omitempty"`
+}
+
+func (m *GetMetricsRequest) Reset()         { *m = GetMetricsRequest{} }
+func (m *GetMetricsRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*GetMetricsRequest) ProtoMessage()    {}
+
+type MetricValue struct {
+	MetricName  string `protobuf:"bytes,1,opt,name=metricName,proto3" json:"metricName,omitempty"`
+	MetricValue int64  `protobuf:"varint,2,opt,name=metricValue,proto3" json:"metricValue,omitempty"`
+}
+
+func (m *MetricValue) Reset()         { *m = MetricValue{} }
+func (m *MetricValue) String() string { return fmt.Sprintf("%+v", *m) }
+func (*MetricValue) ProtoMessage()    {}
+
+type GetMetricsResponse struct {
+	MetricValues []*MetricValue `protobuf:"bytes,1,rep,name=metricValues,proto3" json:"metricValues,omitempty"`
+}
+
+func (m *GetMetricsResponse) Reset()         { *m = GetMetricsResponse{} }
+func (m *GetMetricsResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*GetMetricsResponse) ProtoMessage()    {}